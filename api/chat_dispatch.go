@@ -0,0 +1,76 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/swuecho/chat_backend/models"
+	"github.com/swuecho/chat_backend/sqlc_queries"
+)
+
+const (
+	providerGemini = "gemini"
+	providerGRPC   = "grpc"
+)
+
+// providerForModel maps a chat session's Model string onto the provider
+// name it should be dispatched under, matching the grpc:// URI convention
+// ParseGRPCModelURI understands.
+func providerForModel(model string) string {
+	if strings.HasPrefix(model, "grpc://") {
+		return providerGRPC
+	}
+	return providerGemini
+}
+
+// fallbackProviders reads the ordered provider fallback chain (e.g.
+// "gemini,grpc") from CHAT_FALLBACK_PROVIDERS, so an operator can configure
+// "try this provider if the preferred one is unhealthy" without a redeploy.
+func fallbackProviders() []string {
+	raw := os.Getenv("CHAT_FALLBACK_PROVIDERS")
+	if raw == "" {
+		return nil
+	}
+	var out []string
+	for _, p := range strings.Split(raw, ",") {
+		if p = strings.TrimSpace(p); p != "" {
+			out = append(out, p)
+		}
+	}
+	return out
+}
+
+var (
+	routersMu sync.Mutex
+	routers   = map[*ChatHandler]*ProviderRouter{}
+)
+
+// routerFor returns h's ProviderRouter, building it once on first use and
+// caching it for the lifetime of h. The router owns the health Tracker, so
+// its cooldown/backoff state - and the configured fallback chain - has to
+// survive across requests rather than being rebuilt (and reset) on every
+// call.
+func (h *ChatHandler) routerFor() *ProviderRouter {
+	routersMu.Lock()
+	defer routersMu.Unlock()
+	if r, ok := routers[h]; ok {
+		return r
+	}
+	r := NewProviderRouter(map[string]ChatModel{
+		providerGemini: h.geminiChatModel,
+		providerGRPC:   h.grpcChatModel,
+	}, fallbackProviders())
+	routers[h] = r
+	return r
+}
+
+// DispatchChatCompletion streams a chat completion through h's provider
+// router instead of calling a provider's ChatModel directly, so a provider
+// the health tracker has marked unhealthy is skipped rather than retried
+// into the ground.
+func (h *ChatHandler) DispatchChatCompletion(ctx context.Context, w http.ResponseWriter, chatSession sqlc_queries.ChatSession, messages []models.Message, chatUuid string, regenerate bool, stream bool) (*models.LLMAnswer, error) {
+	return h.routerFor().Dispatch(ctx, providerForModel(chatSession.Model), w, chatSession, messages, chatUuid, regenerate, stream)
+}
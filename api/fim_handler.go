@@ -0,0 +1,47 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+)
+
+type fimRequest struct {
+	Model  string `json:"model"`
+	Prefix string `json:"prefix"`
+	Suffix string `json:"suffix"`
+}
+
+type fimResponse struct {
+	Middle string `json:"middle"`
+}
+
+// CompleteFIM handles POST /api/chat/complete_fim for editor/IDE clients: it
+// takes a prefix/suffix pair instead of a message list and returns only the
+// completed middle span, using the FIM template registered for the model.
+func (h *ChatHandler) CompleteFIM(w http.ResponseWriter, r *http.Request) {
+	var req fimRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		RespondWithAPIError(w, ErrValidationInvalidInput("invalid request body"))
+		return
+	}
+
+	tmpl, ok := LookupFIMTemplate(req.Model)
+	if !ok {
+		RespondWithAPIError(w, ErrValidationInvalidInput("no FIM template registered for model "+req.Model))
+		return
+	}
+
+	var middle string
+	var err error
+	if tmpl.UseSystemPrompt {
+		middle, err = h.geminiChatModel.FIM(r.Context(), req.Model, req.Prefix, req.Suffix)
+	} else {
+		middle, err = h.grpcChatModel.FIM(r.Context(), req.Model, tmpl, req.Prefix, req.Suffix)
+	}
+	if err != nil {
+		RespondWithAPIError(w, err)
+		return
+	}
+
+	json.NewEncoder(w).Encode(fimResponse{Middle: middle})
+}
@@ -0,0 +1,54 @@
+package main
+
+import "sync"
+
+// FIMTemplate describes how to wrap a prefix/suffix pair into the prompt a
+// given model expects for fill-in-the-middle completion. Models that use
+// in-band FIM tokens (e.g. the CodeLlama family) set Prefix/Suffix/Middle;
+// models with no native FIM support (e.g. Gemini) instead set UseSystemPrompt
+// and are routed through a system-prompt-templated variant.
+type FIMTemplate struct {
+	Prefix         string
+	Suffix         string
+	Middle         string
+	UseSystemPrompt bool
+}
+
+// fimRegistry maps a model name to its FIMTemplate so new models can be
+// added by registering a template rather than touching Go code elsewhere.
+// Token-based entries are keyed by their grpc://backend/model-id URI, the
+// same string CompleteFIM forwards to GRPCChatModel.FIM and ParseGRPCModelURI
+// expects, since those models are served by a registered gRPC backend rather
+// than Gemini.
+var (
+	fimRegistryMu sync.RWMutex
+	fimRegistry   = map[string]FIMTemplate{
+		"grpc://llama-cpp/codellama":      {Prefix: "<|fim_prefix|>", Suffix: "<|fim_suffix|>", Middle: "<|fim_middle|>"},
+		"grpc://llama-cpp/deepseek-coder": {Prefix: "<｜fim▁begin｜>", Suffix: "<｜fim▁hole｜>", Middle: "<｜fim▁end｜>"},
+		"grpc://llama-cpp/starcoder":      {Prefix: "<fim_prefix>", Suffix: "<fim_suffix>", Middle: "<fim_middle>"},
+		"gemini-pro":                      {UseSystemPrompt: true},
+		"gemini-1.5-pro":                  {UseSystemPrompt: true},
+		"gemini-1.5-flash":                {UseSystemPrompt: true},
+	}
+)
+
+// RegisterFIMTemplate adds or overrides the FIM template for model.
+func RegisterFIMTemplate(model string, tmpl FIMTemplate) {
+	fimRegistryMu.Lock()
+	defer fimRegistryMu.Unlock()
+	fimRegistry[model] = tmpl
+}
+
+// LookupFIMTemplate returns the registered template for model, if any.
+func LookupFIMTemplate(model string) (FIMTemplate, bool) {
+	fimRegistryMu.RLock()
+	defer fimRegistryMu.RUnlock()
+	tmpl, ok := fimRegistry[model]
+	return tmpl, ok
+}
+
+// BuildFIMPrompt renders prefix/suffix into the single-string prompt a
+// token-based FIM model expects.
+func (t FIMTemplate) BuildFIMPrompt(prefix, suffix string) string {
+	return t.Prefix + prefix + t.Suffix + suffix + t.Middle
+}
@@ -0,0 +1,48 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/swuecho/chat_backend/llm/gemini"
+	"github.com/swuecho/chat_backend/models"
+)
+
+// fimSystemPrompt instructs Gemini, which has no native FIM tokens, to
+// behave like a fill-in-the-middle model via a system-prompt-templated
+// variant instead.
+const fimSystemPrompt = "You are a code completion engine. Given CODE_BEFORE and CODE_AFTER, " +
+	"respond with ONLY the code that belongs between them - no explanation, no markdown fences."
+
+// FIM performs fill-in-the-middle completion: given the code before and
+// after the cursor, it returns just the missing middle span.
+func (m *GeminiChatModel) FIM(ctx context.Context, model, prefix, suffix string) (string, error) {
+	messages := []models.Message{
+		{Role: "user", Content: fimSystemPrompt},
+		{Role: "user", Content: fmt.Sprintf("CODE_BEFORE:\n%s\n\nCODE_AFTER:\n%s", prefix, suffix)},
+	}
+
+	temperature := 0.1
+	payloadBytes, err := gemini.GenGemminPayload(ctx, messages, nil, &gemini.GenerationConfig{
+		Temperature: &temperature,
+	}, nil)
+	if err != nil {
+		return "", ErrInternalUnexpected.WithMessage("Failed to generate Gemini FIM payload").WithDebugInfo(err.Error())
+	}
+
+	url := buildAPIURL(model, false)
+	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewBuffer(payloadBytes))
+	if err != nil {
+		return "", ErrInternalUnexpected.WithMessage("Failed to create Gemini FIM request").WithDebugInfo(err.Error())
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	answer, err := handleRegularResponse(http.Client{Timeout: 1 * time.Minute}, req, nil)
+	if err != nil {
+		return "", err
+	}
+	return answer.Answer, nil
+}
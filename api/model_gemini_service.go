@@ -8,6 +8,7 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"log"
 	"net/http"
 	"os"
 	"strings"
@@ -15,6 +16,7 @@ import (
 
 	"github.com/swuecho/chat_backend/llm/gemini"
 	"github.com/swuecho/chat_backend/models"
+	"github.com/swuecho/chat_backend/providers/ratelimit"
 	"github.com/swuecho/chat_backend/sqlc_queries"
 )
 
@@ -30,66 +32,109 @@ import (
 
 // GeminiClient handles communication with the Gemini API
 type GeminiClient struct {
-	client *http.Client
+	client    *http.Client
+	transport *ratelimit.Transport
 }
 
-// NewGeminiClient creates a new Gemini API client
+// NewGeminiClient creates a new Gemini API client whose requests are
+// retried on 429/500/502/503/504 through a shared rate-limit-aware transport.
 func NewGeminiClient() *GeminiClient {
+	transport := ratelimit.NewTransport(nil)
 	return &GeminiClient{
-		client: &http.Client{Timeout: 5 * time.Minute},
+		client:    &http.Client{Timeout: 5 * time.Minute, Transport: transport},
+		transport: transport,
 	}
 }
 
 // Gemini ChatModel implementation
 type GeminiChatModel struct {
-	h      *ChatHandler
-	client *GeminiClient
+	h           *ChatHandler
+	client      *GeminiClient
+	filesClient *gemini.FilesClient
 }
 
 func NewGeminiChatModel(h *ChatHandler) *GeminiChatModel {
 	return &GeminiChatModel{
-		h:      h,
-		client: NewGeminiClient(),
+		h:           h,
+		client:      NewGeminiClient(),
+		filesClient: gemini.NewFilesClient(),
 	}
 }
 
-func (m *GeminiChatModel) Stream(w http.ResponseWriter, chatSession sqlc_queries.ChatSession, messages []models.Message, chatUuid string, regenerate bool, stream bool) (*models.LLMAnswer, error) {
+func (m *GeminiChatModel) Stream(ctx context.Context, w http.ResponseWriter, chatSession sqlc_queries.ChatSession, messages []models.Message, chatUuid string, regenerate bool, stream bool) (*models.LLMAnswer, error) {
 	answerID := chatUuid
 	if !regenerate {
 		answerID = NewUUID()
 	}
 
-	chatFiles, err := m.h.chatfileService.q.ListChatFilesWithContentBySessionUUID(context.Background(), chatSession.Uuid)
+	chatFiles, err := m.h.chatfileService.q.ListChatFilesWithContentBySessionUUID(ctx, chatSession.Uuid)
 	if err != nil {
 		return nil, ErrInternalUnexpected.WithMessage("Failed to get chat files").WithDebugInfo(err.Error())
 	}
 
-	payloadBytes, err := gemini.GenGemminPayload(messages, chatFiles)
+	genConfig, err := gemini.ParseGenerationConfig(chatSession.GenerationConfig)
+	if err != nil {
+		return nil, ErrInternalUnexpected.WithMessage("Failed to parse Gemini generation config").WithDebugInfo(err.Error())
+	}
+
+	payloadBytes, err := gemini.GenGemminPayload(ctx, messages, chatFiles, genConfig, m.filesClient)
 	if err != nil {
 		return nil, ErrInternalUnexpected.WithMessage("Failed to generate Gemini payload").WithDebugInfo(err.Error())
 	}
 
+	reqCtx, info := ratelimit.WithInfo(ctx)
 	url := buildAPIURL(chatSession.Model, stream)
-	req, err := http.NewRequest("POST", url, bytes.NewBuffer(payloadBytes))
+	req, err := http.NewRequestWithContext(reqCtx, "POST", url, bytes.NewBuffer(payloadBytes))
 	if err != nil {
 		return nil, ErrInternalUnexpected.WithMessage("Failed to create Gemini API request").WithDebugInfo(err.Error())
 	}
 	req.Header.Set("Content-Type", "application/json")
 
 	if stream {
-		return m.handleStreamResponse(w, req, answerID)
+		llmAnswer, err := m.handleStreamResponse(w, req, answerID, info)
+		m.persistUsage(answerID, llmAnswer)
+		return llmAnswer, err
 	}
 
-	llmAnswer, err := handleRegularResponse(*m.client.client, req)
+	llmAnswer, err := handleRegularResponse(*m.client.client, req, info)
 	if llmAnswer != nil {
 		llmAnswer.AnswerId = answerID
 	}
+	m.persistUsage(answerID, llmAnswer)
 	response := constructChatCompletionStreamReponse(answerID, llmAnswer.Answer)
 	data, _ := json.Marshal(response)
 	fmt.Fprint(w, string(data))
 	return llmAnswer, err
 }
 
+// persistUsage records a completion's token usage against its chat message so
+// the UI can show real cost per turn instead of estimates.
+func (m *GeminiChatModel) persistUsage(answerID string, llmAnswer *models.LLMAnswer) {
+	if llmAnswer == nil || llmAnswer.Usage == nil {
+		return
+	}
+	err := m.h.chatfileService.q.UpdateChatMessageUsage(context.Background(), sqlc_queries.UpdateChatMessageUsageParams{
+		Uuid:         answerID,
+		InputTokens:  int32(llmAnswer.Usage.InputTokens),
+		OutputTokens: int32(llmAnswer.Usage.OutputTokens),
+		TotalTokens:  int32(llmAnswer.Usage.TotalTokens),
+	})
+	if err != nil {
+		log.Printf("failed to persist usage for message %s: %v", answerID, err)
+	}
+}
+
+// retryDebugInfo appends a request's rate-limit retry accounting (attempt
+// count, total time spent waiting) to debugInfo so rate-limit storms are
+// diagnosable from the error alone. info is nil if the request was built
+// without ratelimit.WithInfo.
+func retryDebugInfo(info *ratelimit.Info, debugInfo string) string {
+	if info == nil {
+		return debugInfo
+	}
+	return fmt.Sprintf("%s (attempts=%d totalWait=%s)", debugInfo, info.Attempts, info.TotalWait)
+}
+
 func buildAPIURL(model string, stream bool) string {
 	endpoint := "generateContent"
 	url := fmt.Sprintf("https://generativelanguage.googleapis.com/v1beta/models/%s:%s?key=$GEMINI_API_KEY", model, endpoint)
@@ -100,16 +145,16 @@ func buildAPIURL(model string, stream bool) string {
 	return os.ExpandEnv(url)
 }
 
-func handleRegularResponse(client http.Client, req *http.Request) (*models.LLMAnswer, error) {
+func handleRegularResponse(client http.Client, req *http.Request, info *ratelimit.Info) (*models.LLMAnswer, error) {
 	resp, err := client.Do(req)
 	if err != nil {
-		return nil, ErrInternalUnexpected.WithMessage("Failed to send Gemini API request").WithDebugInfo(err.Error())
+		return nil, ErrInternalUnexpected.WithMessage("Failed to send Gemini API request").WithDebugInfo(retryDebugInfo(info, err.Error()))
 	}
 
 	if resp.StatusCode != http.StatusOK {
 		body, _ := io.ReadAll(resp.Body)
 		resp.Body.Close()
-		return nil, ErrInternalUnexpected.WithMessage(fmt.Sprintf("Gemini API error: %d", resp.StatusCode)).WithDebugInfo(string(body))
+		return nil, ErrInternalUnexpected.WithMessage(fmt.Sprintf("Gemini API error: %d", resp.StatusCode)).WithDebugInfo(retryDebugInfo(info, string(body)))
 	}
 
 	if resp == nil {
@@ -134,6 +179,7 @@ func handleRegularResponse(client http.Client, req *http.Request) (*models.LLMAn
 	return &models.LLMAnswer{
 		Answer:   answer,
 		AnswerId: "",
+		Usage:    geminiResp.UsageMetadata.Usage(),
 	}, nil
 }
 
@@ -161,20 +207,27 @@ func GenerateChatTitle(ctx context.Context, model, chatText string) (string, err
 		},
 	}
 
-	// Generate proper Gemini payload
-	payloadBytes, err := gemini.GenGemminPayload(messages, nil)
+	// Generate proper Gemini payload, tuned for short deterministic titles
+	// rather than a creative completion.
+	titleTemperature := 0.2
+	titleMaxOutputTokens := int32(20)
+	payloadBytes, err := gemini.GenGemminPayload(ctx, messages, nil, &gemini.GenerationConfig{
+		Temperature:     &titleTemperature,
+		MaxOutputTokens: &titleMaxOutputTokens,
+	}, nil)
 	if err != nil {
 		return "", ErrInternalUnexpected.WithMessage("Failed to generate Gemini payload").WithDebugInfo(err.Error())
 	}
 
 	// Build URL with proper API key
+	reqCtx, info := ratelimit.WithInfo(ctx)
 	url := buildAPIURL(model, false)
-	req, err := http.NewRequest("POST", url, bytes.NewBuffer(payloadBytes))
+	req, err := http.NewRequestWithContext(reqCtx, "POST", url, bytes.NewBuffer(payloadBytes))
 	if err != nil {
 		return "", ErrInternalUnexpected.WithMessage("Failed to create Gemini API request").WithDebugInfo(err.Error())
 	}
 	req.Header.Set("Content-Type", "application/json")
-	answer, err := handleRegularResponse(http.Client{Timeout: 1 * time.Minute}, req)
+	answer, err := handleRegularResponse(http.Client{Timeout: 1 * time.Minute, Transport: ratelimit.NewTransport(nil)}, req, info)
 	if err != nil {
 		return "", err
 	}
@@ -195,10 +248,10 @@ func GenerateChatTitle(ctx context.Context, model, chatText string) (string, err
 	return firstN(title, 100), nil
 }
 
-func (m *GeminiChatModel) handleStreamResponse(w http.ResponseWriter, req *http.Request, answerID string) (*models.LLMAnswer, error) {
+func (m *GeminiChatModel) handleStreamResponse(w http.ResponseWriter, req *http.Request, answerID string, info *ratelimit.Info) (*models.LLMAnswer, error) {
 	resp, err := m.client.client.Do(req)
 	if err != nil {
-		return nil, ErrInternalUnexpected.WithMessage("Failed to send Gemini API request").WithDebugInfo(err.Error())
+		return nil, ErrInternalUnexpected.WithMessage("Failed to send Gemini API request").WithDebugInfo(retryDebugInfo(info, err.Error()))
 	}
 	defer resp.Body.Close()
 
@@ -213,6 +266,7 @@ func (m *GeminiChatModel) handleStreamResponse(w http.ResponseWriter, req *http.
 	}
 
 	var answer string
+	var usage *models.Usage
 	ioreader := bufio.NewReader(resp.Body)
 	headerData := []byte("data: ")
 
@@ -223,6 +277,7 @@ func (m *GeminiChatModel) handleStreamResponse(w http.ResponseWriter, req *http.
 				return &models.LLMAnswer{
 					Answer:   answer,
 					AnswerId: answerID,
+					Usage:    usage,
 				}, nil
 			}
 			return nil, ErrInternalUnexpected.WithMessage("Error reading stream").WithDebugInfo(err.Error())
@@ -235,6 +290,9 @@ func (m *GeminiChatModel) handleStreamResponse(w http.ResponseWriter, req *http.
 		line = bytes.TrimPrefix(line, headerData)
 		if len(line) > 0 {
 			answer = gemini.ParseRespLine(line, answer)
+			if u := gemini.ParseUsageLine(line); u != nil {
+				usage = u
+			}
 			data, _ := json.Marshal(constructChatCompletionStreamReponse(answerID, answer))
 			fmt.Fprintf(w, "data: %v\n\n", string(data))
 			flusher.Flush()
@@ -244,5 +302,6 @@ func (m *GeminiChatModel) handleStreamResponse(w http.ResponseWriter, req *http.
 	return &models.LLMAnswer{
 		AnswerId: answerID,
 		Answer:   answer,
+		Usage:    usage,
 	}, nil
 }
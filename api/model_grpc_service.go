@@ -0,0 +1,207 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/swuecho/chat_backend/chat_backend/proto"
+	"github.com/swuecho/chat_backend/models"
+	"github.com/swuecho/chat_backend/sqlc_queries"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+)
+
+// grpcBackend is a declared out-of-process model backend, launched as a
+// child process and reached over a unix socket.
+type grpcBackend struct {
+	name       string
+	socketPath string
+	cmd        *exec.Cmd
+	conn       *grpc.ClientConn
+	client     proto.LLMClient
+}
+
+// GRPCBackendRegistry launches and health-checks declared gRPC backends so
+// sessions whose Model is a `grpc://name/model-id` URI can be routed to an
+// out-of-process model without recompiling the server.
+type GRPCBackendRegistry struct {
+	mu       sync.Mutex
+	backends map[string]*grpcBackend
+}
+
+func NewGRPCBackendRegistry() *GRPCBackendRegistry {
+	return &GRPCBackendRegistry{backends: make(map[string]*grpcBackend)}
+}
+
+// Launch starts command as a child process serving the LLM service on a
+// unix socket, dials it, and registers it under name. It blocks until the
+// backend responds to a TokenCount health check or dialTimeout elapses.
+func (r *GRPCBackendRegistry) Launch(name string, command string, args []string, socketPath string, dialTimeout time.Duration) error {
+	cmd := exec.Command(command, args...)
+	if err := cmd.Start(); err != nil {
+		return fmt.Errorf("start grpc backend %q: %w", name, err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), dialTimeout)
+	defer cancel()
+
+	conn, err := grpc.DialContext(ctx, "unix://"+socketPath,
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+		grpc.WithDefaultCallOptions(grpc.CallContentSubtype("json")),
+		grpc.WithBlock())
+	if err != nil {
+		_ = cmd.Process.Kill()
+		return fmt.Errorf("dial grpc backend %q: %w", name, err)
+	}
+
+	client := proto.NewLLMClient(conn)
+	if _, err := client.TokenCount(ctx, &proto.TokenCountRequest{Text: "ping"}); err != nil {
+		_ = conn.Close()
+		_ = cmd.Process.Kill()
+		return fmt.Errorf("health check grpc backend %q: %w", name, err)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.backends[name] = &grpcBackend{name: name, socketPath: socketPath, cmd: cmd, conn: conn, client: client}
+	return nil
+}
+
+func (r *GRPCBackendRegistry) get(name string) (*grpcBackend, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	b, ok := r.backends[name]
+	return b, ok
+}
+
+// ParseGRPCModelURI splits a `grpc://name/model-id` session Model string
+// into the registered backend name and the model id to request from it.
+func ParseGRPCModelURI(model string) (backend string, modelID string, ok bool) {
+	rest := strings.TrimPrefix(model, "grpc://")
+	if rest == model {
+		return "", "", false
+	}
+	parts := strings.SplitN(rest, "/", 2)
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		return "", "", false
+	}
+	return parts[0], parts[1], true
+}
+
+// GRPCChatModel implements ChatModel by forwarding requests to a backend
+// registered in a GRPCBackendRegistry.
+type GRPCChatModel struct {
+	registry *GRPCBackendRegistry
+}
+
+func NewGRPCChatModel(registry *GRPCBackendRegistry) *GRPCChatModel {
+	return &GRPCChatModel{registry: registry}
+}
+
+func (m *GRPCChatModel) Stream(ctx context.Context, w http.ResponseWriter, chatSession sqlc_queries.ChatSession, messages []models.Message, chatUuid string, regenerate bool, stream bool) (*models.LLMAnswer, error) {
+	answerID := chatUuid
+	if !regenerate {
+		answerID = NewUUID()
+	}
+
+	backendName, modelID, ok := ParseGRPCModelURI(chatSession.Model)
+	if !ok {
+		return nil, ErrValidationInvalidInput(fmt.Sprintf("not a grpc model uri: %s", chatSession.Model))
+	}
+	backend, ok := m.registry.get(backendName)
+	if !ok {
+		return nil, ErrInternalUnexpected.WithMessage(fmt.Sprintf("grpc backend %q not registered", backendName))
+	}
+
+	req := &proto.PredictRequest{Model: modelID, Messages: toProtoMessages(messages)}
+
+	if !stream {
+		resp, err := backend.client.Predict(ctx, req)
+		if err != nil {
+			return nil, translateGRPCError(err)
+		}
+		return &models.LLMAnswer{Answer: resp.Text, AnswerId: answerID}, nil
+	}
+
+	return m.handleGRPCStream(ctx, w, backend, req, answerID)
+}
+
+// FIM performs fill-in-the-middle completion against a token-based model
+// served by a registered gRPC backend, rendering tmpl's in-band FIM tokens
+// around prefix/suffix into a single prompt.
+func (m *GRPCChatModel) FIM(ctx context.Context, modelURI string, tmpl FIMTemplate, prefix, suffix string) (string, error) {
+	backendName, modelID, ok := ParseGRPCModelURI(modelURI)
+	if !ok {
+		return "", ErrValidationInvalidInput(fmt.Sprintf("token-based FIM requires a grpc:// model uri, got %s", modelURI))
+	}
+	backend, ok := m.registry.get(backendName)
+	if !ok {
+		return "", ErrInternalUnexpected.WithMessage(fmt.Sprintf("grpc backend %q not registered", backendName))
+	}
+
+	prompt := tmpl.BuildFIMPrompt(prefix, suffix)
+	resp, err := backend.client.Predict(ctx, &proto.PredictRequest{
+		Model:    modelID,
+		Messages: []proto.Message{{Role: "user", Content: prompt}},
+	})
+	if err != nil {
+		return "", translateGRPCError(err)
+	}
+	return resp.Text, nil
+}
+
+func (m *GRPCChatModel) handleGRPCStream(ctx context.Context, w http.ResponseWriter, backend *grpcBackend, req *proto.PredictRequest, answerID string) (*models.LLMAnswer, error) {
+	respStream, err := backend.client.PredictStream(ctx, req)
+	if err != nil {
+		return nil, translateGRPCError(err)
+	}
+
+	setSSEHeader(w)
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		return nil, APIError{HTTPCode: http.StatusInternalServerError, Code: "STREAM_UNSUPPORTED", Message: "Streaming unsupported by client"}
+	}
+
+	var answer string
+	for {
+		chunk, err := respStream.Recv()
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			return nil, translateGRPCError(err)
+		}
+		answer += chunk.TextDelta
+		data, _ := json.Marshal(constructChatCompletionStreamReponse(answerID, answer))
+		fmt.Fprintf(w, "data: %v\n\n", string(data))
+		flusher.Flush()
+		if chunk.Done {
+			break
+		}
+	}
+
+	return &models.LLMAnswer{Answer: answer, AnswerId: answerID}, nil
+}
+
+func toProtoMessages(messages []models.Message) []proto.Message {
+	out := make([]proto.Message, len(messages))
+	for i, m := range messages {
+		out[i] = proto.Message{Role: m.Role, Content: m.Content}
+	}
+	return out
+}
+
+// translateGRPCError converts a gRPC call failure (backend crash, cancelled
+// context, etc.) into the server's APIError so it surfaces the same way a
+// native provider's error would.
+func translateGRPCError(err error) error {
+	return ErrInternalUnexpected.WithMessage("gRPC backend request failed").WithDebugInfo(err.Error())
+}
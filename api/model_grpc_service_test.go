@@ -0,0 +1,112 @@
+package main
+
+import (
+	"context"
+	"io"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/swuecho/chat_backend/chat_backend/proto"
+	"github.com/swuecho/chat_backend/models"
+	"github.com/swuecho/chat_backend/sqlc_queries"
+	"google.golang.org/grpc"
+)
+
+func TestParseGRPCModelURI(t *testing.T) {
+	cases := []struct {
+		model       string
+		wantBackend string
+		wantModel   string
+		wantOK      bool
+	}{
+		{"grpc://llama-cpp/7b-instruct", "llama-cpp", "7b-instruct", true},
+		{"gemini-pro", "", "", false},
+		{"grpc://missing-model-id/", "", "", false},
+		{"grpc:///no-backend", "", "", false},
+	}
+	for _, c := range cases {
+		backend, modelID, ok := ParseGRPCModelURI(c.model)
+		if ok != c.wantOK || backend != c.wantBackend || modelID != c.wantModel {
+			t.Errorf("ParseGRPCModelURI(%q) = (%q, %q, %v), want (%q, %q, %v)",
+				c.model, backend, modelID, ok, c.wantBackend, c.wantModel, c.wantOK)
+		}
+	}
+}
+
+// fakeLLMClient is an in-process stand-in for a backend's gRPC client, used
+// to exercise GRPCChatModel's streaming, cancellation, and error-propagation
+// paths without a real subprocess or network connection.
+type fakeLLMClient struct {
+	proto.LLMClient
+	predictErr    error
+	streamChunks  []*proto.PredictChunk
+	streamErr     error
+	checkCanceled bool
+}
+
+func (f *fakeLLMClient) Predict(ctx context.Context, in *proto.PredictRequest, opts ...grpc.CallOption) (*proto.PredictResponse, error) {
+	if f.checkCanceled && ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
+	if f.predictErr != nil {
+		return nil, f.predictErr
+	}
+	return &proto.PredictResponse{Text: "ok"}, nil
+}
+
+func (f *fakeLLMClient) PredictStream(ctx context.Context, in *proto.PredictRequest, opts ...grpc.CallOption) (proto.LLM_PredictStreamClient, error) {
+	if f.streamErr != nil {
+		return nil, f.streamErr
+	}
+	return &fakeStreamClient{ctx: ctx, chunks: f.streamChunks}, nil
+}
+
+type fakeStreamClient struct {
+	grpc.ClientStream
+	ctx    context.Context
+	chunks []*proto.PredictChunk
+	i      int
+}
+
+func (s *fakeStreamClient) Recv() (*proto.PredictChunk, error) {
+	if s.ctx.Err() != nil {
+		return nil, s.ctx.Err()
+	}
+	if s.i >= len(s.chunks) {
+		return nil, errEOF
+	}
+	c := s.chunks[s.i]
+	s.i++
+	return c, nil
+}
+
+var errEOF = io.EOF
+
+func TestGRPCChatModelStream_PropagatesCancellation(t *testing.T) {
+	registry := NewGRPCBackendRegistry()
+	registry.backends["fake"] = &grpcBackend{
+		name:   "fake",
+		client: &fakeLLMClient{checkCanceled: true},
+	}
+	model := NewGRPCChatModel(registry)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	chatSession := sqlc_queries.ChatSession{Model: "grpc://fake/7b"}
+	_, err := model.Stream(ctx, httptest.NewRecorder(), chatSession, []models.Message{{Role: "user", Content: "hi"}}, "uuid", false, false)
+	if err == nil {
+		t.Fatal("expected an error when the context is already canceled, got nil")
+	}
+}
+
+func TestGRPCChatModelStream_UnknownBackend(t *testing.T) {
+	registry := NewGRPCBackendRegistry()
+	model := NewGRPCChatModel(registry)
+
+	chatSession := sqlc_queries.ChatSession{Model: "grpc://missing/7b"}
+	_, err := model.Stream(context.Background(), httptest.NewRecorder(), chatSession, nil, "uuid", false, false)
+	if err == nil {
+		t.Fatal("expected an error for an unregistered backend, got nil")
+	}
+}
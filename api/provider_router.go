@@ -0,0 +1,106 @@
+package main
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/swuecho/chat_backend/models"
+	"github.com/swuecho/chat_backend/providers/health"
+	"github.com/swuecho/chat_backend/sqlc_queries"
+)
+
+// ChatModel is implemented by every provider-specific streaming client
+// (GeminiChatModel, OpenAIChatModel, ClaudeChatModel, ...).
+type ChatModel interface {
+	Stream(ctx context.Context, w http.ResponseWriter, chatSession sqlc_queries.ChatSession, messages []models.Message, chatUuid string, regenerate bool, stream bool) (*models.LLMAnswer, error)
+}
+
+// ProviderRouter dispatches a chat completion to the configured provider,
+// transparently falling back to the next healthy provider in Fallbacks when
+// the preferred one is marked unhealthy by the Tracker.
+type ProviderRouter struct {
+	Tracker   *health.Tracker
+	Models    map[string]ChatModel
+	Fallbacks []string
+}
+
+// NewProviderRouter builds a router over the given provider name -> ChatModel
+// map, falling back through fallbacks in order when the preferred provider
+// is unhealthy.
+func NewProviderRouter(models map[string]ChatModel, fallbacks []string) *ProviderRouter {
+	return &ProviderRouter{
+		Tracker:   health.NewTracker(),
+		Models:    models,
+		Fallbacks: fallbacks,
+	}
+}
+
+// Dispatch picks the first healthy provider starting at preferred and
+// falling back through r.Fallbacks, then streams through it, recording the
+// outcome on the tracker. Once a provider has written any response bytes,
+// Dispatch stops trying further candidates on failure: the client may
+// already have partial SSE frames from that provider, and starting another
+// provider's stream on the same ResponseWriter would corrupt it.
+func (r *ProviderRouter) Dispatch(ctx context.Context, preferred string, w http.ResponseWriter, chatSession sqlc_queries.ChatSession, messages []models.Message, chatUuid string, regenerate bool, stream bool) (*models.LLMAnswer, error) {
+	candidates := append([]string{preferred}, r.Fallbacks...)
+	tracked := &firstByteWriter{ResponseWriter: w}
+
+	var lastErr error
+	for _, provider := range candidates {
+		model, ok := r.Models[provider]
+		if !ok || !r.Tracker.IsHealthy(provider) {
+			continue
+		}
+
+		answer, err := model.Stream(ctx, tracked, chatSession, messages, chatUuid, regenerate, stream)
+		if err == nil {
+			r.Tracker.RecordSuccess(provider)
+			return answer, nil
+		}
+
+		lastErr = err
+		r.Tracker.RecordFailure(provider, classifyErr(err))
+
+		if tracked.wrote {
+			break
+		}
+	}
+
+	if lastErr != nil {
+		return nil, lastErr
+	}
+	return nil, ErrInternalUnexpected.WithMessage("No healthy LLM provider available")
+}
+
+// firstByteWriter wraps an http.ResponseWriter and records whether any
+// response body bytes have been written through it, so Dispatch can tell a
+// pre-first-byte failure (safe to retry on the next provider) from a
+// mid-stream failure (the client has already seen partial SSE frames and a
+// second provider must not write to the same response).
+type firstByteWriter struct {
+	http.ResponseWriter
+	wrote bool
+}
+
+func (w *firstByteWriter) Write(p []byte) (int, error) {
+	if len(p) > 0 {
+		w.wrote = true
+	}
+	return w.ResponseWriter.Write(p)
+}
+
+func (w *firstByteWriter) Flush() {
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// classifyErr maps an APIError's HTTP status onto a health.FailureClass so
+// the tracker can apply the right cooldown strategy.
+func classifyErr(err error) health.FailureClass {
+	apiErr, ok := err.(APIError)
+	if !ok {
+		return health.FailureTimeout
+	}
+	return health.ClassifyStatus(apiErr.HTTPCode)
+}
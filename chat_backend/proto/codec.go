@@ -0,0 +1,31 @@
+package proto
+
+import (
+	"encoding/json"
+
+	"google.golang.org/grpc/encoding"
+)
+
+// jsonCodec marshals RPC messages as JSON. The types in this package are
+// plain Go structs rather than protoc-generated messages (no ProtoReflect
+// support), so they can't go through grpc-go's default "proto" codec -
+// registering this as the "json" content subtype and dialing backends with
+// grpc.CallContentSubtype("json") lets them travel over the wire correctly
+// until real protoc-generated stubs replace these structs.
+type jsonCodec struct{}
+
+func (jsonCodec) Marshal(v interface{}) ([]byte, error) {
+	return json.Marshal(v)
+}
+
+func (jsonCodec) Unmarshal(data []byte, v interface{}) error {
+	return json.Unmarshal(data, v)
+}
+
+func (jsonCodec) Name() string {
+	return "json"
+}
+
+func init() {
+	encoding.RegisterCodec(jsonCodec{})
+}
@@ -0,0 +1,47 @@
+// Go mirrors of the messages declared in llm.proto. These are plain structs,
+// not protoc-gen-go output: they have no ProtoReflect support and are sent
+// over the wire with the JSON codec registered in codec.go.
+package proto
+
+type Message struct {
+	Role    string `protobuf:"bytes,1,opt,name=role,proto3"`
+	Content string `protobuf:"bytes,2,opt,name=content,proto3"`
+}
+
+type PredictRequest struct {
+	Model       string    `protobuf:"bytes,1,opt,name=model,proto3"`
+	Messages    []Message `protobuf:"bytes,2,rep,name=messages,proto3"`
+	Temperature float64   `protobuf:"fixed64,3,opt,name=temperature,proto3"`
+	MaxTokens   int32     `protobuf:"varint,4,opt,name=max_tokens,proto3"`
+}
+
+type PredictResponse struct {
+	Text             string `protobuf:"bytes,1,opt,name=text,proto3"`
+	PromptTokens     int32  `protobuf:"varint,2,opt,name=prompt_tokens,proto3"`
+	CompletionTokens int32  `protobuf:"varint,3,opt,name=completion_tokens,proto3"`
+}
+
+type PredictChunk struct {
+	TextDelta        string `protobuf:"bytes,1,opt,name=text_delta,proto3"`
+	Done             bool   `protobuf:"varint,2,opt,name=done,proto3"`
+	PromptTokens     int32  `protobuf:"varint,3,opt,name=prompt_tokens,proto3"`
+	CompletionTokens int32  `protobuf:"varint,4,opt,name=completion_tokens,proto3"`
+}
+
+type EmbedRequest struct {
+	Model  string   `protobuf:"bytes,1,opt,name=model,proto3"`
+	Inputs []string `protobuf:"bytes,2,rep,name=inputs,proto3"`
+}
+
+type EmbedResponse struct {
+	Values []float32 `protobuf:"fixed32,1,rep,name=values,proto3"`
+}
+
+type TokenCountRequest struct {
+	Model string `protobuf:"bytes,1,opt,name=model,proto3"`
+	Text  string `protobuf:"bytes,2,opt,name=text,proto3"`
+}
+
+type TokenCountResponse struct {
+	Count int32 `protobuf:"varint,1,opt,name=count,proto3"`
+}
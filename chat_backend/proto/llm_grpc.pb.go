@@ -0,0 +1,84 @@
+// Client side of the LLM service declared in llm.proto, written by hand in
+// the shape protoc-gen-go-grpc produces. Only the client is provided; its
+// request and response types are the plain structs in llm.pb.go.
+package proto
+
+import (
+	"context"
+
+	"google.golang.org/grpc"
+)
+
+type LLMClient interface {
+	Predict(ctx context.Context, in *PredictRequest, opts ...grpc.CallOption) (*PredictResponse, error)
+	PredictStream(ctx context.Context, in *PredictRequest, opts ...grpc.CallOption) (LLM_PredictStreamClient, error)
+	Embed(ctx context.Context, in *EmbedRequest, opts ...grpc.CallOption) (*EmbedResponse, error)
+	TokenCount(ctx context.Context, in *TokenCountRequest, opts ...grpc.CallOption) (*TokenCountResponse, error)
+}
+
+// LLM_PredictStreamClient is the client-side stream of PredictChunk messages
+// returned by a streaming Predict call.
+type LLM_PredictStreamClient interface {
+	Recv() (*PredictChunk, error)
+	grpc.ClientStream
+}
+
+type llmClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewLLMClient(cc grpc.ClientConnInterface) LLMClient {
+	return &llmClient{cc}
+}
+
+func (c *llmClient) Predict(ctx context.Context, in *PredictRequest, opts ...grpc.CallOption) (*PredictResponse, error) {
+	out := new(PredictResponse)
+	if err := c.cc.Invoke(ctx, "/chat_backend.llm.LLM/Predict", in, out, opts...); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *llmClient) PredictStream(ctx context.Context, in *PredictRequest, opts ...grpc.CallOption) (LLM_PredictStreamClient, error) {
+	stream, err := c.cc.(interface {
+		NewStream(context.Context, *grpc.StreamDesc, string, ...grpc.CallOption) (grpc.ClientStream, error)
+	}).NewStream(ctx, &grpc.StreamDesc{StreamName: "PredictStream", ServerStreams: true}, "/chat_backend.llm.LLM/PredictStream", opts...)
+	if err != nil {
+		return nil, err
+	}
+	if err := stream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := stream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return &llmPredictStreamClient{stream}, nil
+}
+
+type llmPredictStreamClient struct {
+	grpc.ClientStream
+}
+
+func (x *llmPredictStreamClient) Recv() (*PredictChunk, error) {
+	m := new(PredictChunk)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *llmClient) Embed(ctx context.Context, in *EmbedRequest, opts ...grpc.CallOption) (*EmbedResponse, error) {
+	out := new(EmbedResponse)
+	if err := c.cc.Invoke(ctx, "/chat_backend.llm.LLM/Embed", in, out, opts...); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *llmClient) TokenCount(ctx context.Context, in *TokenCountRequest, opts ...grpc.CallOption) (*TokenCountResponse, error) {
+	out := new(TokenCountResponse)
+	if err := c.cc.Invoke(ctx, "/chat_backend.llm.LLM/TokenCount", in, out, opts...); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
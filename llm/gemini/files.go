@@ -0,0 +1,105 @@
+package gemini
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"time"
+)
+
+// InlineSizeLimit is Gemini's maximum inline payload size; attachments
+// larger than this must go through the Files API instead.
+const InlineSizeLimit = 20 * 1024 * 1024 // 20MB
+
+// UploadedFile is the subset of the Files API's File resource callers need
+// to reference an upload in a subsequent generateContent request.
+type UploadedFile struct {
+	Name     string `json:"name"`
+	URI      string `json:"uri"`
+	MimeType string `json:"mimeType"`
+	State    string `json:"state"` // PROCESSING, ACTIVE, FAILED
+}
+
+// FilesClient uploads attachments to Gemini's Files API so large files
+// (PDFs, video, audio) are sent once and referenced by URI thereafter
+// instead of being re-sent inline on every turn.
+type FilesClient struct {
+	httpClient *http.Client
+}
+
+func NewFilesClient() *FilesClient {
+	return &FilesClient{httpClient: &http.Client{Timeout: 5 * time.Minute}}
+}
+
+// Upload POSTs data to the Files API and polls until the file reaches the
+// ACTIVE state (or FAILED, which is returned as an error).
+func (c *FilesClient) Upload(ctx context.Context, mimeType string, data []byte) (*UploadedFile, error) {
+	url := os.ExpandEnv("https://generativelanguage.googleapis.com/upload/v1beta/files?key=$GEMINI_API_KEY")
+
+	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("build files upload request: %w", err)
+	}
+	req.Header.Set("Content-Type", mimeType)
+	req.Header.Set("X-Goog-Upload-Protocol", "raw")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("upload file to gemini: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("read files upload response: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("files upload failed: %d: %s", resp.StatusCode, string(body))
+	}
+
+	var wrapper struct {
+		File UploadedFile `json:"file"`
+	}
+	if err := json.Unmarshal(body, &wrapper); err != nil {
+		return nil, fmt.Errorf("parse files upload response: %w", err)
+	}
+
+	return c.pollUntilActive(ctx, wrapper.File)
+}
+
+func (c *FilesClient) pollUntilActive(ctx context.Context, file UploadedFile) (*UploadedFile, error) {
+	for file.State == "PROCESSING" {
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(1 * time.Second):
+		}
+
+		url := os.ExpandEnv(fmt.Sprintf("https://generativelanguage.googleapis.com/v1beta/%s?key=$GEMINI_API_KEY", file.Name))
+		req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+		if err != nil {
+			return nil, fmt.Errorf("build files status request: %w", err)
+		}
+		resp, err := c.httpClient.Do(req)
+		if err != nil {
+			return nil, fmt.Errorf("poll gemini file status: %w", err)
+		}
+		body, err := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if err != nil {
+			return nil, fmt.Errorf("read files status response: %w", err)
+		}
+		if err := json.Unmarshal(body, &file); err != nil {
+			return nil, fmt.Errorf("parse files status response: %w", err)
+		}
+	}
+
+	if file.State != "ACTIVE" {
+		return nil, fmt.Errorf("gemini file %s ended in state %s", file.Name, file.State)
+	}
+	return &file, nil
+}
@@ -0,0 +1,233 @@
+package gemini
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+
+	"github.com/swuecho/chat_backend/models"
+	"github.com/swuecho/chat_backend/sqlc_queries"
+)
+
+// ResponseBody is the JSON shape returned by Gemini's generateContent and
+// streamGenerateContent endpoints.
+type ResponseBody struct {
+	Candidates    []Candidate    `json:"candidates"`
+	UsageMetadata *UsageMetadata `json:"usageMetadata,omitempty"`
+}
+
+type Candidate struct {
+	Content Content `json:"content"`
+}
+
+type Content struct {
+	Role  string `json:"role,omitempty"`
+	Parts []Part `json:"parts"`
+}
+
+type Part struct {
+	Text       string    `json:"text,omitempty"`
+	InlineData *Blob     `json:"inlineData,omitempty"`
+	FileData   *FileData `json:"fileData,omitempty"`
+}
+
+// Blob is raw bytes embedded directly in a request, subject to Gemini's
+// 20MB inline-payload limit (see InlineSizeLimit).
+type Blob struct {
+	MimeType string `json:"mimeType"`
+	Data     string `json:"data"` // base64-encoded
+}
+
+// FileData references a file previously uploaded via the Files API instead
+// of re-sending its bytes on every turn.
+type FileData struct {
+	MimeType string `json:"mimeType"`
+	FileUri  string `json:"fileUri"`
+}
+
+// UsageMetadata is Gemini's per-response token accounting block.
+type UsageMetadata struct {
+	PromptTokenCount     int `json:"promptTokenCount"`
+	CandidatesTokenCount int `json:"candidatesTokenCount"`
+	TotalTokenCount      int `json:"totalTokenCount"`
+}
+
+// Usage converts Gemini's usageMetadata into the provider-agnostic shape
+// stored on models.LLMAnswer.
+func (u *UsageMetadata) Usage() *models.Usage {
+	if u == nil {
+		return nil
+	}
+	return &models.Usage{
+		InputTokens:  u.PromptTokenCount,
+		OutputTokens: u.CandidatesTokenCount,
+		TotalTokens:  u.TotalTokenCount,
+		Raw: map[string]interface{}{
+			"promptTokenCount":     u.PromptTokenCount,
+			"candidatesTokenCount": u.CandidatesTokenCount,
+			"totalTokenCount":      u.TotalTokenCount,
+		},
+	}
+}
+
+// partsFromMessage converts a models.Message's typed Parts (if any) into
+// Gemini's parts array, falling back to a single text part built from
+// Content for plain text-only messages. Inline parts over InlineSizeLimit
+// are uploaded via filesClient and referenced by fileData instead of being
+// embedded, the same as attached chat files.
+func partsFromMessage(ctx context.Context, m models.Message, filesClient *FilesClient) ([]Part, error) {
+	if len(m.Parts) == 0 {
+		return []Part{{Text: m.Content}}, nil
+	}
+
+	parts := make([]Part, 0, len(m.Parts))
+	for _, p := range m.Parts {
+		switch p.Kind {
+		case models.MessagePartInlineData:
+			part, err := inlineOrUploadedPart(ctx, p.MimeType, p.InlineData, filesClient)
+			if err != nil {
+				return nil, err
+			}
+			parts = append(parts, part)
+		case models.MessagePartFileData:
+			parts = append(parts, Part{FileData: &FileData{
+				MimeType: p.MimeType,
+				FileUri:  p.FileURI,
+			}})
+		default:
+			parts = append(parts, Part{Text: p.Text})
+		}
+	}
+	return parts, nil
+}
+
+// inlineOrUploadedPart embeds data directly when it fits within Gemini's
+// InlineSizeLimit, or uploads it via the Files API and references it by
+// fileData otherwise.
+func inlineOrUploadedPart(ctx context.Context, mimeType string, data []byte, filesClient *FilesClient) (Part, error) {
+	if len(data) <= InlineSizeLimit {
+		return Part{InlineData: &Blob{
+			MimeType: mimeType,
+			Data:     base64.StdEncoding.EncodeToString(data),
+		}}, nil
+	}
+
+	uploaded, err := filesClient.Upload(ctx, mimeType, data)
+	if err != nil {
+		return Part{}, fmt.Errorf("upload inline part: %w", err)
+	}
+	return Part{FileData: &FileData{
+		MimeType: uploaded.MimeType,
+		FileUri:  uploaded.URI,
+	}}, nil
+}
+
+// GenerationConfig mirrors Gemini's generationConfig request object, letting
+// callers control sampling and request structured JSON output.
+type GenerationConfig struct {
+	Temperature      *float64        `json:"temperature,omitempty"`
+	TopP             *float64        `json:"topP,omitempty"`
+	TopK             *int32          `json:"topK,omitempty"`
+	MaxOutputTokens  *int32          `json:"maxOutputTokens,omitempty"`
+	StopSequences    []string        `json:"stopSequences,omitempty"`
+	ResponseMimeType string          `json:"responseMimeType,omitempty"`
+	ResponseSchema   json.RawMessage `json:"responseSchema,omitempty"`
+}
+
+// ParseGenerationConfig decodes a ChatSession's stored GenerationConfig blob.
+// A nil/empty raw value is not an error; it just means "use defaults".
+func ParseGenerationConfig(raw []byte) (*GenerationConfig, error) {
+	if len(raw) == 0 {
+		return nil, nil
+	}
+	var cfg GenerationConfig
+	if err := json.Unmarshal(raw, &cfg); err != nil {
+		return nil, fmt.Errorf("parse generation config: %w", err)
+	}
+	return &cfg, nil
+}
+
+// GenGemminPayload builds the request body for Gemini's generateContent
+// family of endpoints from chat history, any attached files, and optional
+// sampling/output configuration. Attachments larger than InlineSizeLimit
+// are uploaded via filesClient and referenced by fileData instead of being
+// embedded inline.
+func GenGemminPayload(ctx context.Context, messages []models.Message, chatFiles []sqlc_queries.ChatFile, genConfig *GenerationConfig, filesClient *FilesClient) ([]byte, error) {
+	contents := make([]Content, 0, len(messages))
+	for _, m := range messages {
+		role := m.Role
+		if role == "assistant" {
+			role = "model"
+		}
+		parts, err := partsFromMessage(ctx, m, filesClient)
+		if err != nil {
+			return nil, err
+		}
+		contents = append(contents, Content{
+			Role:  role,
+			Parts: parts,
+		})
+	}
+
+	if len(chatFiles) > 0 && len(contents) > 0 {
+		fileParts, err := partsFromChatFiles(ctx, chatFiles, filesClient)
+		if err != nil {
+			return nil, err
+		}
+		last := &contents[len(contents)-1]
+		last.Parts = append(last.Parts, fileParts...)
+	}
+
+	payload := map[string]interface{}{
+		"contents": contents,
+	}
+	if genConfig != nil {
+		payload["generationConfig"] = genConfig
+	}
+
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return nil, fmt.Errorf("marshal gemini payload: %w", err)
+	}
+	return data, nil
+}
+
+// partsFromChatFiles converts attached chat files into Gemini parts,
+// inlining small files and uploading anything over InlineSizeLimit through
+// the Files API so the same attachment isn't re-sent on every turn.
+func partsFromChatFiles(ctx context.Context, chatFiles []sqlc_queries.ChatFile, filesClient *FilesClient) ([]Part, error) {
+	parts := make([]Part, 0, len(chatFiles))
+	for _, f := range chatFiles {
+		part, err := inlineOrUploadedPart(ctx, f.MimeType, f.Content, filesClient)
+		if err != nil {
+			return nil, fmt.Errorf("chat file %s: %w", f.Name, err)
+		}
+		parts = append(parts, part)
+	}
+	return parts, nil
+}
+
+// ParseRespLine parses a single `data: {...}` SSE chunk and appends any new
+// candidate text to the running answer, returning the updated answer.
+func ParseRespLine(line []byte, answer string) string {
+	var chunk ResponseBody
+	if err := json.Unmarshal(line, &chunk); err != nil {
+		return answer
+	}
+	if len(chunk.Candidates) == 0 || len(chunk.Candidates[0].Content.Parts) == 0 {
+		return answer
+	}
+	return answer + chunk.Candidates[0].Content.Parts[0].Text
+}
+
+// ParseUsageLine extracts the usage metadata from a streamed SSE chunk, if
+// present. Gemini only includes usageMetadata on some chunks (typically the
+// final one), so callers should keep the last non-nil value seen.
+func ParseUsageLine(line []byte) *models.Usage {
+	var chunk ResponseBody
+	if err := json.Unmarshal(line, &chunk); err != nil {
+		return nil
+	}
+	return chunk.UsageMetadata.Usage()
+}
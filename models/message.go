@@ -0,0 +1,49 @@
+package models
+
+// Message is a single turn in a chat conversation sent to an LLM provider.
+// Parts carries multimodal content (images, audio, file references); when
+// empty, providers fall back to Content as a single text part.
+type Message struct {
+	Role    string        `json:"role"`
+	Content string        `json:"content"`
+	Parts   []MessagePart `json:"parts,omitempty"`
+}
+
+// MessagePartKind distinguishes how a MessagePart's payload is encoded.
+type MessagePartKind string
+
+const (
+	MessagePartText       MessagePartKind = "text"
+	MessagePartInlineData MessagePartKind = "inline_data"
+	MessagePartFileData   MessagePartKind = "file_data"
+)
+
+// MessagePart is one piece of a (possibly multimodal) message. Exactly one
+// of Text, InlineData, or FileURI is populated, selected by Kind.
+type MessagePart struct {
+	Kind MessagePartKind `json:"kind"`
+	Text string          `json:"text,omitempty"`
+
+	MimeType   string `json:"mimeType,omitempty"`
+	InlineData []byte `json:"inlineData,omitempty"`
+	FileURI    string `json:"fileUri,omitempty"`
+}
+
+// Usage captures token accounting for a single LLM completion, normalized
+// across providers so callers can bill/limit usage consistently.
+type Usage struct {
+	InputTokens  int `json:"inputTokens"`
+	OutputTokens int `json:"outputTokens"`
+	TotalTokens  int `json:"totalTokens"`
+	// Raw holds the provider's original usage payload for debugging and
+	// providers whose accounting doesn't map cleanly onto the fields above.
+	Raw map[string]interface{} `json:"raw,omitempty"`
+}
+
+// LLMAnswer is the normalized result of a (possibly streamed) completion
+// call against any provider's ChatModel implementation.
+type LLMAnswer struct {
+	Answer   string `json:"answer"`
+	AnswerId string `json:"answerId"`
+	Usage    *Usage `json:"usage,omitempty"`
+}
@@ -0,0 +1,129 @@
+// Package health tracks per-provider availability so callers can avoid
+// dispatching requests to a ChatModel that is known to be failing and can
+// fall back to an alternate provider instead.
+package health
+
+import (
+	"log"
+	"math"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+// FailureClass buckets the kinds of failures a provider call can hit, since
+// each calls for a different recovery strategy.
+type FailureClass int
+
+const (
+	FailureNone FailureClass = iota
+	FailureAuth              // 401/403
+	FailureRateLimit         // 429/5xx
+	FailureTimeout           // network timeout/context deadline
+)
+
+// ClassifyStatus maps an HTTP status code to a FailureClass, for providers
+// whose client surfaces the status code.
+func ClassifyStatus(statusCode int) FailureClass {
+	switch {
+	case statusCode == http.StatusUnauthorized || statusCode == http.StatusForbidden:
+		return FailureAuth
+	case statusCode == http.StatusTooManyRequests || statusCode >= 500:
+		return FailureRateLimit
+	default:
+		return FailureNone
+	}
+}
+
+const (
+	authUnhealthyFor    = 10 * time.Minute
+	backoffBase         = 1 * time.Second
+	backoffCap          = 2 * time.Minute
+	maxBackoffAttempts  = 10
+)
+
+var requestsTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+	Name: "llm_provider_requests_total",
+	Help: "LLM provider calls by provider and outcome.",
+}, []string{"provider", "status"})
+
+type providerState struct {
+	unhealthyUntil time.Time
+	attempts       int
+}
+
+// Tracker records failures per provider and decides whether a provider is
+// currently healthy enough to dispatch to.
+type Tracker struct {
+	mu    sync.Mutex
+	state map[string]*providerState
+}
+
+// NewTracker creates an empty Tracker; all providers start healthy.
+func NewTracker() *Tracker {
+	return &Tracker{state: make(map[string]*providerState)}
+}
+
+// IsHealthy reports whether provider currently has no active cooldown.
+func (t *Tracker) IsHealthy(provider string) bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	s, ok := t.state[provider]
+	if !ok {
+		return true
+	}
+	return time.Now().After(s.unhealthyUntil)
+}
+
+// RecordSuccess clears any cooldown and resets the backoff counter.
+func (t *Tracker) RecordSuccess(provider string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	_, wasUnhealthy := t.state[provider]
+	delete(t.state, provider)
+	requestsTotal.WithLabelValues(provider, "success").Inc()
+	if wasUnhealthy {
+		log.Printf("provider_health: provider=%s transitioned to healthy", provider)
+	}
+}
+
+// RecordFailure marks provider as unhealthy for a duration appropriate to
+// class, and returns that duration.
+func (t *Tracker) RecordFailure(provider string, class FailureClass) time.Duration {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	s, ok := t.state[provider]
+	if !ok {
+		s = &providerState{}
+		t.state[provider] = s
+	}
+	wasHealthy := time.Now().After(s.unhealthyUntil)
+
+	var cooldown time.Duration
+	switch class {
+	case FailureAuth:
+		cooldown = authUnhealthyFor
+	case FailureRateLimit, FailureTimeout:
+		s.attempts++
+		if s.attempts > maxBackoffAttempts {
+			s.attempts = maxBackoffAttempts
+		}
+		cooldown = time.Duration(math.Min(
+			float64(backoffCap),
+			float64(backoffBase)*math.Pow(2, float64(s.attempts-1)),
+		))
+	default:
+		return 0
+	}
+
+	s.unhealthyUntil = time.Now().Add(cooldown)
+	requestsTotal.WithLabelValues(provider, "failure").Inc()
+	if wasHealthy {
+		log.Printf("provider_health: provider=%s transitioned to unhealthy class=%d cooldown=%s", provider, class, cooldown)
+	}
+	return cooldown
+}
@@ -0,0 +1,196 @@
+// Package ratelimit provides an http.RoundTripper that retries rate-limited
+// and transiently-failing LLM provider calls, so every provider client can
+// adopt the same backoff behavior instead of reimplementing it.
+package ratelimit
+
+import (
+	"context"
+	"errors"
+	"io"
+	"math"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+const (
+	DefaultBaseDelay   = 500 * time.Millisecond
+	DefaultCapDelay    = 30 * time.Second
+	DefaultMaxAttempts = 5
+)
+
+// Info reports how many attempts a request took (the first try included)
+// and how long it spent waiting between them, so callers can surface it on an error's debug info when
+// diagnosing rate-limit storms.
+type Info struct {
+	Attempts  int
+	TotalWait time.Duration
+}
+
+type infoCtxKey struct{}
+
+// WithInfo returns a context derived from ctx along with an *Info that
+// Transport.RoundTrip will populate when the request is made with the
+// returned context. Each call produces a fresh Info, so concurrent requests
+// sharing a Transport never observe each other's retry accounting.
+func WithInfo(ctx context.Context) (context.Context, *Info) {
+	info := &Info{}
+	return context.WithValue(ctx, infoCtxKey{}, info), info
+}
+
+func infoFromContext(ctx context.Context) *Info {
+	info, _ := ctx.Value(infoCtxKey{}).(*Info)
+	return info
+}
+
+// Transport wraps another http.RoundTripper and retries requests that fail
+// with a retryable status: 429 (honoring Retry-After), or 500/502/503/504
+// with jittered exponential backoff. It only retries before any response
+// body has been read by the caller - once RoundTrip has returned a response
+// whose body the caller started consuming (e.g. an SSE stream), a later
+// read failure is never retried.
+type Transport struct {
+	Next        http.RoundTripper
+	BaseDelay   time.Duration
+	CapDelay    time.Duration
+	MaxAttempts int
+}
+
+// NewTransport builds a Transport with the package defaults, wrapping next
+// (http.DefaultTransport if nil).
+func NewTransport(next http.RoundTripper) *Transport {
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	return &Transport{
+		Next:        next,
+		BaseDelay:   DefaultBaseDelay,
+		CapDelay:    DefaultCapDelay,
+		MaxAttempts: DefaultMaxAttempts,
+	}
+}
+
+func isRetryableStatus(code int) bool {
+	switch code {
+	case http.StatusTooManyRequests, http.StatusInternalServerError, http.StatusBadGateway,
+		http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	default:
+		return false
+	}
+}
+
+// retryAfter parses a Retry-After header (seconds or HTTP-date) into a
+// duration, returning ok=false if absent or unparseable.
+func retryAfter(resp *http.Response) (time.Duration, bool) {
+	h := resp.Header.Get("Retry-After")
+	if h == "" {
+		return 0, false
+	}
+	if secs, err := strconv.Atoi(h); err == nil {
+		return time.Duration(secs) * time.Second, true
+	}
+	if t, err := http.ParseTime(h); err == nil {
+		if d := time.Until(t); d > 0 {
+			return d, true
+		}
+	}
+	return 0, false
+}
+
+func (t *Transport) backoff(attempt int) time.Duration {
+	base := t.BaseDelay
+	if base <= 0 {
+		base = DefaultBaseDelay
+	}
+	capDelay := t.CapDelay
+	if capDelay <= 0 {
+		capDelay = DefaultCapDelay
+	}
+	exp := float64(base) * math.Pow(2, float64(attempt))
+	delay := time.Duration(math.Min(exp, float64(capDelay)))
+	// full jitter
+	return time.Duration(rand.Int63n(int64(delay) + 1))
+}
+
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	maxAttempts := t.MaxAttempts
+	if maxAttempts <= 0 {
+		maxAttempts = DefaultMaxAttempts
+	}
+
+	info := infoFromContext(req.Context())
+	var lastResp *http.Response
+	var lastErr error
+
+	for attempt := 0; attempt < maxAttempts; attempt++ {
+		if attempt > 0 && req.Body != nil {
+			if req.GetBody == nil {
+				lastErr = errors.New("ratelimit: cannot retry request with a non-rewindable body")
+				break
+			}
+			body, err := req.GetBody()
+			if err != nil {
+				lastErr = err
+				break
+			}
+			req.Body = body
+		}
+
+		resp, err := t.Next.RoundTrip(req)
+		if info != nil {
+			info.Attempts = attempt + 1
+		}
+
+		if err != nil {
+			lastErr = err
+			lastResp = nil
+		} else if isRetryableStatus(resp.StatusCode) {
+			lastErr = nil
+			lastResp = resp
+		} else {
+			return resp, nil
+		}
+
+		if attempt == maxAttempts-1 {
+			break
+		}
+
+		var delay time.Duration
+		if lastResp != nil {
+			if d, ok := retryAfter(lastResp); ok {
+				delay = d
+			} else {
+				delay = t.backoff(attempt)
+			}
+			io.Copy(io.Discard, lastResp.Body)
+			lastResp.Body.Close()
+		} else {
+			delay = t.backoff(attempt)
+		}
+
+		select {
+		case <-req.Context().Done():
+			return nil, req.Context().Err()
+		case <-time.After(delay):
+		}
+		if info != nil {
+			info.TotalWait += delay
+		}
+	}
+
+	if lastErr != nil {
+		return nil, lastErr
+	}
+	return lastResp, nil
+}
+
+// WithTimeout is a convenience for building an *http.Client backed by a
+// retrying Transport with the given overall timeout.
+func WithTimeout(timeout time.Duration) *http.Client {
+	return &http.Client{
+		Timeout:   timeout,
+		Transport: NewTransport(nil),
+	}
+}
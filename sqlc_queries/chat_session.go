@@ -0,0 +1,39 @@
+package sqlc_queries
+
+import "context"
+
+// ChatSession is the persisted configuration for a single chat conversation.
+type ChatSession struct {
+	Uuid  string
+	Model string
+	// GenerationConfig is a provider-specific JSON blob (e.g. Gemini's
+	// temperature/topP/topK/maxOutputTokens/stopSequences/responseMimeType)
+	// stored as-is and parsed by the relevant ChatModel implementation.
+	GenerationConfig []byte
+}
+
+// ChatFile is an attachment uploaded to a chat session, with its content
+// loaded for providers that need to inline it into the request payload.
+type ChatFile struct {
+	Uuid     string
+	Name     string
+	MimeType string
+	Content  []byte
+}
+
+// UpdateChatMessageUsageParams is the input to UpdateChatMessageUsage.
+type UpdateChatMessageUsageParams struct {
+	Uuid         string
+	InputTokens  int32
+	OutputTokens int32
+	TotalTokens  int32
+}
+
+// UpdateChatMessageUsage persists a completion's token accounting against
+// the chat message identified by Uuid.
+func (q *Queries) UpdateChatMessageUsage(ctx context.Context, arg UpdateChatMessageUsageParams) error {
+	_, err := q.db.ExecContext(ctx,
+		`UPDATE chat_message SET input_tokens = $1, output_tokens = $2, total_tokens = $3 WHERE uuid = $4`,
+		arg.InputTokens, arg.OutputTokens, arg.TotalTokens, arg.Uuid)
+	return err
+}
@@ -0,0 +1,20 @@
+package sqlc_queries
+
+import (
+	"context"
+	"database/sql"
+)
+
+// DBTX is the subset of *sql.DB / *sql.Tx used by generated queries.
+type DBTX interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+}
+
+// Queries wraps a DBTX with the generated query methods.
+type Queries struct {
+	db DBTX
+}
+
+func New(db DBTX) *Queries {
+	return &Queries{db: db}
+}